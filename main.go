@@ -2,18 +2,26 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"unsafe"
 )
 
 // ConvertTo converts a value of T1 type to value of T2 type
 //
-// It panics if one of followings met:
+// It returns a *ConvertError if one of followings is met:
 //
-//	If T1's Kind is not a Pointer to Struct or T2's Kind is not a struct
-//	If T1 and T2 does not share the same layout memory (ref examples below for details)
-//	If T2 is larger than T1
-//	If T1's underlying type is T2
-//	Currently if T1 or T2's underlying field Kind is not one of following: Bool, Int*, Float*, Uint, Uint8, Uint16, Uint32, Uint64, Float*
+//	If T1's Kind is not a Pointer to Struct or T2's Kind is not a struct (ErrNotPointerToStruct)
+//	If T1 and T2 does not share the same layout memory, ref examples below for details (ErrKindMismatch / ErrLayoutMismatch)
+//	If T2 is larger than T1 (ErrT2Larger)
+//	If T1's underlying type is T2 (ErrSameUnderlyingType)
+//	Currently if T1 or T2's underlying field Kind is not one of following: Bool, Int*, Uint*, Uintptr, Float*, Complex64, Complex128, String (ErrUnsupportedKind)
+//	If T1 is a nil pointer (ErrNilPointer)
+//	Pointer, Struct and Array fields are walked recursively and must satisfy the same rules
+//
+// Use MustConvertTo if you want the old panic-on-failure behavior, and
+// CanConvert to check whether T1 and T2 can be converted without an actual
+// T1 value in hand.
 //
 // Pros and cons of using this function are
 //
@@ -66,56 +74,499 @@ import (
 //		t3 string
 //	}
 //
+// note T2 having the same t3 string field at the same offset as T1 would be allowed,
+// string is a layout-stable field kind just like the numeric ones above
+//
 // type T1 T2 is also avoided since it's unnecessary to use this func instead of usual castings
-func ConvertTo[T1, T2 any](t1 T1) (t2 T2) {
+func ConvertTo[T1, T2 any](t1 T1) (t2 T2, err error) {
 	ttyp1, ttyp2 := reflect.TypeOf(t1), reflect.TypeOf(t2)
+	if _, err = validateConvertTypes(ttyp1, ttyp2); err != nil {
+		return t2, err
+	}
+	v1 := reflect.ValueOf(t1)
+	if v1.IsNil() {
+		return t2, &ConvertError{Field: -1, T1: ttyp1, T2: ttyp2, Reason: ErrNilPointer}
+	}
+	t2 = *(*T2)(v1.UnsafePointer())
+	return t2, nil
+}
+
+// MustConvertTo behaves like ConvertTo but panics instead of returning an
+// error, preserving ConvertTo's original behavior for call sites that were
+// written before it returned an error.
+func MustConvertTo[T1, T2 any](t1 T1) T2 {
+	t2, err := ConvertTo[T1, T2](t1)
+	if err != nil {
+		panic(err)
+	}
+	return t2
+}
+
+// CanConvert reports whether T1 and T2 satisfy ConvertTo's layout
+// invariants, without requiring an actual T1 value. Callers can use this to
+// gate a conversion at init time instead of discovering a layout mismatch
+// the first time ConvertTo runs.
+func CanConvert[T1, T2 any]() error {
+	var t1 T1
+	var t2 T2
+	_, err := validateConvertTypes(reflect.TypeOf(t1), reflect.TypeOf(t2))
+	return err
+}
+
+// ConvertSlice reinterprets s as a []T2 sharing s's backing array, scaling
+// the length and capacity by sizeof(T1)/sizeof(T2) instead of copying each
+// element the way a plain loop over ConvertTo would. It panics with a
+// *ConvertError under the same conditions as ConvertTo, plus when sizeof(T2)
+// does not evenly divide sizeof(T1).
+//
+// Pros and cons of using this function are the same as ConvertTo's, plus:
+//
+//	Cons:
+//		The returned slice aliases s's backing array: mutating an element
+//		through either slice is visible through the other, and appending to
+//		one can reallocate away from the other without warning
+//
+// Unlike ConvertTo, T1 and T2 here are the struct types themselves rather
+// than a pointer to T1, since a slice already holds addressable elements.
+func ConvertSlice[T1, T2 any](s []T1) []T2 {
+	var t1 T1
+	var t2 T2
+	typ1, typ2 := reflect.TypeOf(t1), reflect.TypeOf(t2)
+	if typ1.Kind() != reflect.Struct || typ2.Kind() != reflect.Struct {
+		panic(&ConvertError{Field: -1, T1: typ1, T2: typ2, Reason: ErrNotStruct})
+	}
+	if typ1.ConvertibleTo(typ2) {
+		panic(&ConvertError{Field: -1, T1: typ1, T2: typ2, Reason: ErrSameUnderlyingType})
+	}
+	if err := checkLayout(typ1, typ2); err != nil {
+		panic(err)
+	}
+	size1, size2 := typ1.Size(), typ2.Size()
+	if size2 == 0 || size1%size2 != 0 {
+		panic(&ConvertError{Field: -1, T1: typ1, T2: typ2, Reason: ErrLayoutMismatch})
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	scale := int(size1 / size2)
+	full := unsafe.Slice((*T2)(unsafe.Pointer(&s[0])), cap(s)*scale)
+	return full[: len(s)*scale : cap(s)*scale]
+}
 
+// validateConvertTypes runs every check ConvertTo needs before it is safe
+// to reinterpret a T1 pointer as a T2, returning the struct type T1 points
+// to on success.
+func validateConvertTypes(ttyp1, ttyp2 reflect.Type) (elmTyp1 reflect.Type, err error) {
 	if ttyp1.Kind() != reflect.Pointer || ttyp1.Elem().Kind() != reflect.Struct {
-		panic("T1 must be a pointer to struct")
+		return nil, &ConvertError{Field: -1, T1: ttyp1, T2: ttyp2, Reason: ErrNotPointerToStruct}
 	}
 	if ttyp2.Kind() != reflect.Struct {
-		panic("T2 must be a struct")
+		return nil, &ConvertError{Field: -1, T1: ttyp1, T2: ttyp2, Reason: ErrNotPointerToStruct}
 	}
 
-	elmTyp1, elmTyp2 := ttyp1.Elem(), ttyp2
-	if elmTyp1.ConvertibleTo(elmTyp2) {
-		panic("T1 must not be a type definition of T2 or vice versa")
+	elmTyp1 = ttyp1.Elem()
+	if elmTyp1.ConvertibleTo(ttyp2) {
+		return nil, &ConvertError{Field: -1, T1: ttyp1, T2: ttyp2, Reason: ErrSameUnderlyingType}
+	}
+	if err := checkLayout(elmTyp1, ttyp2); err != nil {
+		return nil, err
 	}
-	if elmTyp1.NumField() < elmTyp1.NumField() {
-		panic("T2 must be no larger than T1")
+	return elmTyp1, nil
+}
+
+// checkLayout walks elmTyp1 and elmTyp2 field by field and returns a
+// *ConvertError unless elmTyp2 is a valid prefix overlay of elmTyp1: same
+// field count or fewer, same Kind, offset and alignment per field, and no
+// larger overall Size(). Struct and Array fields are descended into
+// recursively so that nested layouts are verified with the same rigor as
+// top-level ones.
+func checkLayout(elmTyp1, elmTyp2 reflect.Type) error {
+	if elmTyp2.NumField() > elmTyp1.NumField() {
+		return &ConvertError{Field: -1, T1: elmTyp1, T2: elmTyp2, Reason: ErrT2Larger}
 	}
 	for i := 0; i < elmTyp2.NumField(); i++ {
-		ftyp2 := elmTyp2.Field(i).Type
-		ftyp1 := elmTyp1.Field(i).Type
+		f1, f2 := elmTyp1.Field(i), elmTyp2.Field(i)
+		ftyp1, ftyp2 := f1.Type, f2.Type
 		if ftyp1.Kind() != ftyp2.Kind() {
-			panic("T2 and T1 must have the same field type")
+			return &ConvertError{Field: i, T1: elmTyp1, T2: elmTyp2, Reason: ErrKindMismatch}
+		}
+		if f1.Offset != f2.Offset || ftyp1.Align() != ftyp2.Align() {
+			return &ConvertError{Field: i, T1: elmTyp1, T2: elmTyp2, Reason: ErrLayoutMismatch}
 		}
-		if ftyp1.Kind() == reflect.Pointer {
-			ftyp1 = ftyp1.Elem()
+		if err := checkFieldKind(ftyp1, ftyp2); err != nil {
+			if cerr, ok := err.(*ConvertError); ok && cerr.Field < 0 {
+				cerr.Field = i
+			}
+			return err
 		}
-		if ftyp2.Kind() == reflect.Pointer {
-			ftyp2 = ftyp2.Elem()
+	}
+	if elmTyp2.Size() > elmTyp1.Size() {
+		return &ConvertError{Field: -1, T1: elmTyp1, T2: elmTyp2, Reason: ErrLayoutMismatch}
+	}
+	return nil
+}
+
+// checkFieldKind validates a single pair of already offset/align-matched
+// field types, descending into Pointer, Struct and Array kinds so that
+// every primitive they eventually bottom out at is a valid layout kind.
+func checkFieldKind(ftyp1, ftyp2 reflect.Type) error {
+	if ftyp1.Kind() != ftyp2.Kind() {
+		return &ConvertError{Field: -1, T1: ftyp1, T2: ftyp2, Reason: ErrKindMismatch}
+	}
+	switch ftyp1.Kind() {
+	case reflect.Pointer:
+		return checkFieldKind(ftyp1.Elem(), ftyp2.Elem())
+	case reflect.Struct:
+		return checkLayout(ftyp1, ftyp2)
+	case reflect.Array:
+		if ftyp1.Len() != ftyp2.Len() {
+			return &ConvertError{Field: -1, T1: ftyp1, T2: ftyp2, Reason: ErrLayoutMismatch}
 		}
+		return checkFieldKind(ftyp1.Elem(), ftyp2.Elem())
+	default:
 		if !isValidKind(ftyp1.Kind()) || !isValidKind(ftyp2.Kind()) {
-			panic("T2 or T1 must be a valid type")
+			return &ConvertError{Field: -1, T1: ftyp1, T2: ftyp2, Reason: ErrUnsupportedKind}
+		}
+		return nil
+	}
+}
+
+// ConvertErrorReason identifies why ConvertTo, MustConvertTo or CanConvert
+// rejected a T1/T2 pair.
+type ConvertErrorReason int
+
+const (
+	// ErrNotPointerToStruct means T1 is not a pointer to struct or T2 is
+	// not a struct.
+	ErrNotPointerToStruct ConvertErrorReason = iota
+	// ErrT2Larger means T2 has more fields than T1.
+	ErrT2Larger
+	// ErrKindMismatch means a field pair has different Kinds.
+	ErrKindMismatch
+	// ErrLayoutMismatch means a field pair, an array length, or the
+	// overall struct Size() disagree between T1 and T2.
+	ErrLayoutMismatch
+	// ErrSameUnderlyingType means T1 is already convertible to T2 through
+	// ordinary Go type conversion, so ConvertTo is unnecessary.
+	ErrSameUnderlyingType
+	// ErrUnsupportedKind means a field's Kind is not one ConvertTo knows
+	// how to lay out safely.
+	ErrUnsupportedKind
+	// ErrNotStruct means T1 or T2 is not a struct. Unlike
+	// ErrNotPointerToStruct this is used where the value itself (not a
+	// pointer to it) must already be a struct, such as ConvertSlice's
+	// element types.
+	ErrNotStruct
+	// ErrNilPointer means T1's type checks out but the actual pointer
+	// value passed to ConvertTo is nil.
+	ErrNilPointer
+)
+
+func (r ConvertErrorReason) String() string {
+	switch r {
+	case ErrNotPointerToStruct:
+		return "T1 must be a pointer to struct and T2 must be a struct"
+	case ErrT2Larger:
+		return "T2 must be no larger than T1"
+	case ErrKindMismatch:
+		return "T2 and T1 must have the same field Kind at each position"
+	case ErrLayoutMismatch:
+		return "T2 and T1 must share the same memory layout"
+	case ErrSameUnderlyingType:
+		return "T1 must not be a type definition of T2 or vice versa"
+	case ErrUnsupportedKind:
+		return "T2 or T1 has a field Kind that is not supported"
+	case ErrNotStruct:
+		return "T1 and T2 must both be structs"
+	case ErrNilPointer:
+		return "T1 must not be a nil pointer"
+	default:
+		return "unknown convert error"
+	}
+}
+
+// ConvertError is returned by ConvertTo, MustConvertTo and CanConvert when
+// T1 and T2 cannot be safely converted. Field is the offending field index,
+// or -1 when the failure is not specific to a single field.
+type ConvertError struct {
+	Field  int
+	T1, T2 reflect.Type
+	Reason ConvertErrorReason
+}
+
+func (e *ConvertError) Error() string {
+	if e.Field < 0 {
+		return fmt.Sprintf("convert %s to %s: %s", e.T1, e.T2, e.Reason)
+	}
+	return fmt.Sprintf("convert %s to %s: %s (field %d)", e.T1, e.T2, e.Reason, e.Field)
+}
+
+// defaultConvertTag is the struct tag used to pair up fields between T1 and
+// T2 when no WithTag option is supplied.
+const defaultConvertTag = "convert"
+
+// convertSafeConfig holds the resolved options for a ConvertToSafe call.
+type convertSafeConfig struct {
+	tag string
+}
+
+// Option configures ConvertToSafe.
+type Option func(*convertSafeConfig)
+
+// WithTag overrides the struct tag name used to pair fields between T1 and
+// T2. The default is "convert".
+func WithTag(tag string) Option {
+	return func(c *convertSafeConfig) {
+		c.tag = tag
+	}
+}
+
+// ConvertToSafe converts a value of T1 to a value of T2 by copying fields
+// through reflection instead of reinterpreting memory, so callers that
+// cannot satisfy ConvertTo's layout invariants (different field order,
+// renamed fields, extra fields on either side) still get a drop-in
+// conversion without hand-writing the copy.
+//
+// Fields are paired up in two passes:
+//
+//	by key, where a field's key is the value of its struct tag (default
+//	`convert:"otherName"`) if it has one and its name otherwise, so a tag
+//	on either T1's or T2's field can name the field it is paired with
+//	positional index, for any fields left unpaired by the above
+//
+// A tagged field is matched only through its tag value, never through its
+// own name, and two tagged fields pair up only when their tag values are
+// equal.
+//
+// A paired field is copied as-is when both sides share the same type, or
+// coerced when both sides are numeric Kinds and the source value fits in
+// the destination type; otherwise a *FieldConvertError is returned.
+//
+// Unlike ConvertTo, both T1 and T2's paired fields must be exported, since
+// reflection cannot read or set unexported struct fields outside of the
+// unsafe tricks ConvertTo itself relies on.
+func ConvertToSafe[T1, T2 any](t1 T1, opts ...Option) (t2 T2, err error) {
+	cfg := convertSafeConfig{tag: defaultConvertTag}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	v1 := reflect.ValueOf(t1)
+	for v1.Kind() == reflect.Pointer {
+		v1 = v1.Elem()
+	}
+	if v1.Kind() != reflect.Struct {
+		return t2, &ConvertError{Field: -1, T1: reflect.TypeOf(t1), T2: reflect.TypeOf(t2), Reason: ErrNotStruct}
+	}
+
+	v2 := reflect.ValueOf(&t2).Elem()
+	if v2.Kind() != reflect.Struct {
+		return t2, &ConvertError{Field: -1, T1: reflect.TypeOf(t1), T2: reflect.TypeOf(t2), Reason: ErrNotStruct}
+	}
+
+	paired := pairFields(v1.Type(), v2.Type(), cfg.tag)
+	for i1, i2 := range paired {
+		f1, f2 := v1.Type().Field(i1), v2.Type().Field(i2)
+		if !f1.IsExported() || !f2.IsExported() {
+			return t2, &FieldConvertError{Field: f1.Name, Reason: fmt.Errorf("field is unexported, reflection cannot read or set it")}
+		}
+		if err := coerceField(v2.Field(i2), v1.Field(i1)); err != nil {
+			return t2, &FieldConvertError{Field: f1.Name, Reason: err}
+		}
+	}
+	return t2, nil
+}
+
+// pairFields returns, for every field of typ1 that was matched to one on
+// typ2, a map of typ1 field index to typ2 field index. Each field is keyed
+// by its tag value, or by its name when it has no tag; fields with equal
+// keys are paired, and whatever is left over falls back to positional index.
+func pairFields(typ1, typ2 reflect.Type, tag string) map[int]int {
+	keyOf := func(f reflect.StructField) string {
+		if name := f.Tag.Get(tag); name != "" {
+			return name
+		}
+		return f.Name
+	}
+
+	byKey2 := make(map[string]int, typ2.NumField())
+	for i := 0; i < typ2.NumField(); i++ {
+		byKey2[keyOf(typ2.Field(i))] = i
+	}
+
+	paired := make(map[int]int, typ2.NumField())
+	used2 := make(map[int]bool, typ2.NumField())
+	var unmatched1 []int
+	for i := 0; i < typ1.NumField(); i++ {
+		f1 := typ1.Field(i)
+		if i2, ok := byKey2[keyOf(f1)]; ok && !used2[i2] {
+			paired[i] = i2
+			used2[i2] = true
+			continue
+		}
+		unmatched1 = append(unmatched1, i)
+	}
+
+	for _, i1 := range unmatched1 {
+		if used2[i1] || i1 >= typ2.NumField() {
+			continue
+		}
+		paired[i1] = i1
+		used2[i1] = true
+	}
+	return paired
+}
+
+// coerceField copies src into dst, converting between compatible numeric
+// Kinds when the types differ. It reports an error instead of copying when
+// the value would not fit or the Kinds are not convertible.
+func coerceField(dst, src reflect.Value) error {
+	if src.Type() == dst.Type() {
+		dst.Set(src)
+		return nil
+	}
+	if !isNumericKind(src.Kind()) || !isNumericKind(dst.Kind()) {
+		return fmt.Errorf("cannot convert %s to %s", src.Type(), dst.Type())
+	}
+	if err := checkNumericFits(src, dst); err != nil {
+		return err
+	}
+	dst.Set(src.Convert(dst.Type()))
+	return nil
+}
+
+// checkNumericFits reports whether src's numeric value can be represented
+// in dst's type without truncation, overflow, or sign reinterpretation.
+// A plain convert-and-compare round trip is not enough here: converting
+// between same-width signed and unsigned Kinds (e.g. int8 <-> uint8)
+// always round-trips bit-for-bit regardless of the value, so a negative
+// int8 would silently become a huge uint8 unless the sign is checked
+// explicitly.
+func checkNumericFits(src, dst reflect.Value) error {
+	switch {
+	case isSignedIntKind(src.Kind()):
+		v := src.Int()
+		switch {
+		case isUnsignedIntKind(dst.Kind()):
+			if v < 0 {
+				return fmt.Errorf("value %d is negative and cannot convert to %s", v, dst.Type())
+			}
+			if dst.OverflowUint(uint64(v)) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+		case isSignedIntKind(dst.Kind()):
+			if dst.OverflowInt(v) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+		case isFloatKind(dst.Kind()):
+			if dst.OverflowFloat(float64(v)) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+		}
+	case isUnsignedIntKind(src.Kind()):
+		v := src.Uint()
+		switch {
+		case isUnsignedIntKind(dst.Kind()):
+			if dst.OverflowUint(v) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+		case isSignedIntKind(dst.Kind()):
+			if v > math.MaxInt64 || dst.OverflowInt(int64(v)) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+		case isFloatKind(dst.Kind()):
+			if dst.OverflowFloat(float64(v)) {
+				return fmt.Errorf("value %d overflows %s", v, dst.Type())
+			}
+		}
+	case isFloatKind(src.Kind()):
+		v := src.Float()
+		switch {
+		case isFloatKind(dst.Kind()):
+			if dst.OverflowFloat(v) {
+				return fmt.Errorf("value %v overflows %s", v, dst.Type())
+			}
+		case isSignedIntKind(dst.Kind()):
+			if v != math.Trunc(v) {
+				return fmt.Errorf("value %v has a fractional part and cannot convert to %s", v, dst.Type())
+			}
+			if v < -(1<<63) || v >= 1<<63 || dst.OverflowInt(int64(v)) {
+				return fmt.Errorf("value %v overflows %s", v, dst.Type())
+			}
+		case isUnsignedIntKind(dst.Kind()):
+			if v != math.Trunc(v) {
+				return fmt.Errorf("value %v has a fractional part and cannot convert to %s", v, dst.Type())
+			}
+			if v < 0 {
+				return fmt.Errorf("value %v is negative and cannot convert to %s", v, dst.Type())
+			}
+			if v >= 1<<64 || dst.OverflowUint(uint64(v)) {
+				return fmt.Errorf("value %v overflows %s", v, dst.Type())
+			}
 		}
 	}
-	t2 = *(*T2)(reflect.ValueOf(t1).UnsafePointer())
-	return
+	return nil
+}
+
+func isSignedIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUnsignedIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	default:
+		return false
+	}
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	return isSignedIntKind(kind) || isUnsignedIntKind(kind) || isFloatKind(kind)
+}
+
+// FieldConvertError reports that a single field could not be copied by
+// ConvertToSafe.
+type FieldConvertError struct {
+	Field  string
+	Reason error
+}
+
+func (e *FieldConvertError) Error() string {
+	return fmt.Sprintf("convert: field %q: %s", e.Field, e.Reason)
+}
+
+func (e *FieldConvertError) Unwrap() error {
+	return e.Reason
 }
 
 var validKinds = [...]reflect.Kind{
 	reflect.Bool,
 	reflect.Int,
 	reflect.Int8,
+	reflect.Int16,
 	reflect.Int32,
 	reflect.Int64,
 	reflect.Uint,
 	reflect.Uint8,
+	reflect.Uint16,
 	reflect.Uint32,
 	reflect.Uint64,
+	reflect.Uintptr,
 	reflect.Float32,
 	reflect.Float64,
+	reflect.Complex64,
+	reflect.Complex128,
+	reflect.String,
 }
 
 func isValidKind(kind reflect.Kind) bool {
@@ -141,6 +592,6 @@ func main() {
 	two := int32(2)
 	t1 := Type1{t1: 1, t2: &two, t3: "test"}
 	fmt.Println(*t1.t2)
-	t2 := ConvertTo[*Type1, Type2](&t1)
+	t2 := MustConvertTo[*Type1, Type2](&t1)
 	fmt.Println(*t2.tt2)
 }
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCheckFieldKindNestedMismatch(t *testing.T) {
+	type arrT1 struct {
+		Arr [2]int32
+	}
+	type arrT2 struct {
+		Arr [2]float32
+	}
+	if _, err := ConvertTo[*arrT1, arrT2](&arrT1{Arr: [2]int32{1, 2}}); err == nil {
+		t.Fatal("expected error for array elem Kind mismatch, got nil")
+	} else {
+		var convErr *ConvertError
+		if !errors.As(err, &convErr) {
+			t.Fatalf("expected *ConvertError, got %T", err)
+		}
+		if convErr.Reason != ErrKindMismatch {
+			t.Fatalf("expected ErrKindMismatch, got %v", convErr.Reason)
+		}
+	}
+
+	type ptrT1 struct {
+		P *int8
+	}
+	type ptrT2 struct {
+		P *int32
+	}
+	v := int8(5)
+	if _, err := ConvertTo[*ptrT1, ptrT2](&ptrT1{P: &v}); err == nil {
+		t.Fatal("expected error for pointer elem Kind mismatch, got nil")
+	}
+}
+
+func TestConvertToSafeUnexportedField(t *testing.T) {
+	type privT1 struct {
+		val int32
+	}
+	type privT2 struct {
+		val int32
+	}
+	_, err := ConvertToSafe[privT1, privT2](privT1{val: 1})
+	if err == nil {
+		t.Fatal("expected error for unexported field, got nil")
+	}
+	var fcErr *FieldConvertError
+	if !errors.As(err, &fcErr) {
+		t.Fatalf("expected *FieldConvertError, got %T", err)
+	}
+}
+
+func TestConvertToSafeExportedCoercion(t *testing.T) {
+	type pubT1 struct {
+		Val int8
+	}
+	type pubT2 struct {
+		Val int32
+	}
+	out, err := ConvertToSafe[pubT1, pubT2](pubT1{Val: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Val != 5 {
+		t.Fatalf("expected 5, got %d", out.Val)
+	}
+}
+
+func TestConvertToSafeRejectsSignReinterpretation(t *testing.T) {
+	type negT1 struct {
+		Val int8
+	}
+	type negT2 struct {
+		Val uint32
+	}
+	if _, err := ConvertToSafe[negT1, negT2](negT1{Val: -1}); err == nil {
+		t.Fatal("expected error converting a negative int8 to uint32, got nil")
+	}
+
+	type bigT1 struct {
+		Val uint64
+	}
+	type bigT2 struct {
+		Val int64
+	}
+	if _, err := ConvertToSafe[bigT1, bigT2](bigT1{Val: math.MaxUint64}); err == nil {
+		t.Fatal("expected error converting math.MaxUint64 to int64, got nil")
+	}
+
+	// same-width unsigned -> signed still works when the value fits
+	out, err := ConvertToSafe[bigT1, bigT2](bigT1{Val: 5})
+	if err != nil {
+		t.Fatalf("unexpected error converting a fitting uint64 to int64: %v", err)
+	}
+	if out.Val != 5 {
+		t.Fatalf("expected 5, got %d", out.Val)
+	}
+}
+
+func TestConvertToRejectsLargerT2Size(t *testing.T) {
+	type sizeT1 struct {
+		A struct{ X int32 }
+	}
+	type sizeT2 struct {
+		A struct {
+			X int32
+			Y int8
+		}
+	}
+	if _, err := ConvertTo[*sizeT1, sizeT2](&sizeT1{}); err == nil {
+		t.Fatal("expected error when T2's nested struct is larger than T1's, got nil")
+	} else {
+		var convErr *ConvertError
+		if !errors.As(err, &convErr) {
+			t.Fatalf("expected *ConvertError, got %T", err)
+		}
+	}
+}
+
+func TestConvertToRejectsExtraFields(t *testing.T) {
+	type smallT1 struct {
+		A int32
+	}
+	type bigT2 struct {
+		A int32
+		B int32
+	}
+	if _, err := ConvertTo[*smallT1, bigT2](&smallT1{A: 1}); err == nil {
+		t.Fatal("expected ErrT2Larger when T2 has more fields than T1, got nil")
+	} else {
+		var convErr *ConvertError
+		if !errors.As(err, &convErr) || convErr.Reason != ErrT2Larger {
+			t.Fatalf("expected ErrT2Larger, got %v", err)
+		}
+	}
+}
+
+func TestConvertToNilPointer(t *testing.T) {
+	type nilT1 struct {
+		A int32
+		B int8
+	}
+	type nilT2 struct {
+		A int32
+	}
+	_, err := ConvertTo[*nilT1, nilT2](nil)
+	if err == nil {
+		t.Fatal("expected ErrNilPointer for a nil T1, got nil")
+	}
+	var convErr *ConvertError
+	if !errors.As(err, &convErr) || convErr.Reason != ErrNilPointer {
+		t.Fatalf("expected ErrNilPointer, got %v", err)
+	}
+}
+
+func TestCanConvertAndMustConvertTo(t *testing.T) {
+	type okT1 struct {
+		A int8
+		B int32
+		C string
+	}
+	type okT2 struct {
+		A int8
+		B int32
+	}
+	if err := CanConvert[*okT1, okT2](); err != nil {
+		t.Fatalf("expected CanConvert to succeed for a compatible pair, got %v", err)
+	}
+	got := MustConvertTo[*okT1, okT2](&okT1{A: 1, B: 2, C: "unused"})
+	if got.A != 1 || got.B != 2 {
+		t.Fatalf("unexpected MustConvertTo result: %+v", got)
+	}
+
+	type badT2 struct {
+		A int8
+		B int32
+		C string
+		D int64
+	}
+	if err := CanConvert[*okT1, badT2](); err == nil {
+		t.Fatal("expected CanConvert to fail when T2 has more fields than T1, got nil")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected MustConvertTo to panic for an incompatible pair")
+		}
+	}()
+	MustConvertTo[*okT1, badT2](&okT1{A: 1, B: 2, C: "unused"})
+}
+
+func TestConvertSlice(t *testing.T) {
+	type elemT1 struct {
+		A int32
+	}
+	type elemT2 struct {
+		B int32
+	}
+	s := make([]elemT1, 2, 4)
+	s[0] = elemT1{A: 1}
+	s[1] = elemT1{A: 2}
+
+	out := ConvertSlice[elemT1, elemT2](s)
+	if len(out) != 2 || cap(out) != 4 {
+		t.Fatalf("expected len=2 cap=4, got len=%d cap=%d", len(out), cap(out))
+	}
+	if out[0].B != 1 || out[1].B != 2 {
+		t.Fatalf("unexpected ConvertSlice contents: %+v", out)
+	}
+
+	out[0].B = 99
+	if s[0].A != 99 {
+		t.Fatal("expected ConvertSlice to alias the source backing array")
+	}
+}
+
+func TestConvertSlicePanicsOnIndivisibleSize(t *testing.T) {
+	type elemT1 struct {
+		A int32
+		B int32
+		C int32
+	}
+	type elemT2 struct {
+		A int32
+		B int32
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected ConvertSlice to panic when sizeof(T2) does not divide sizeof(T1)")
+		}
+	}()
+	ConvertSlice[elemT1, elemT2]([]elemT1{{A: 1, B: 2, C: 3}})
+}